@@ -1,51 +1,153 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"flag"
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
+	"sync"
 	"time"
 
 	rec "simplebankrecon/reconcile"
 )
 
+// bankProfileFlag collects repeated -bank-profile bankname=path.json
+// flags into a name->BankProfile map.
+type bankProfileFlag struct {
+	profiles map[string]*rec.BankProfile
+}
+
+func (f *bankProfileFlag) String() string {
+	return ""
+}
+
+func (f *bankProfileFlag) Set(s string) error {
+	name, path, ok := strings.Cut(s, "=")
+	if !ok || name == "" || path == "" {
+		return fmt.Errorf("expected bankname=path.json, got %q", s)
+	}
+	profile, err := rec.LoadBankProfile(path)
+	if err != nil {
+		return err
+	}
+	if f.profiles == nil {
+		f.profiles = make(map[string]*rec.BankProfile)
+	}
+	f.profiles[name] = profile
+	return nil
+}
+
 func main() {
 	systemPath := flag.String("system", "", "System transaction CSV file path")
 	banksArg := flag.String("bank", "", "Comma-separated list of bank statement CSV file paths")
+	bankFormat := flag.String("bank-format", "csv", "Bank statement file format: csv|mt940")
+	var bankProfiles bankProfileFlag
+	flag.Var(&bankProfiles, "bank-profile", "Per-bank column mapping, bankname=path.json (repeatable)")
+	defaultCurrency := flag.String("default-currency", "USD", "Currency code assumed for rows with no currency column")
+	fxRatesPath := flag.String("fx-rates", "", "Optional FX rates CSV (from,to,rate) enabling cross-currency matching")
+	matchStrategy := flag.String("match-strategy", "greedy", "Bucket matching strategy: greedy|optimal")
+	unmatchPenaltyCents := flag.Int64("unmatch-penalty", 0, "Optimal strategy: cost of leaving a row unmatched, in minor units (0 = default)")
+	maxDeltaCents := flag.Int64("max-delta-cents", 0, "Optimal strategy: reject pairs whose delta exceeds this, in minor units (0 = no cap)")
+	matchRulesPath := flag.String("match-rules", "", "Optional JSON MatchRules file; when set, replaces date+sign bucketing with rule-based matching")
+	stateFilePath := flag.String("state-file", "", "Optional JSON state file enabling incremental reconciliation across runs")
+	reset := flag.Bool("reset", false, "Wipe --state-file and exit, without reconciling")
+	threads := flag.Int("threads", runtime.NumCPU(), "Worker count for bank-file ingestion and per-bucket matching")
 	startStr := flag.String("start", "", "Start date (YYYY-MM-DD) inclusive")
 	endStr := flag.String("end", "", "End date (YYYY-MM-DD) inclusive")
 	out := flag.String("output-json", "", "Optional path to write JSON summary")
 	flag.Parse()
 
+	if *reset {
+		if *stateFilePath == "" {
+			fmt.Fprintln(os.Stderr, "error: --reset requires --state-file")
+			os.Exit(2)
+		}
+		check(rec.NewStateStore(*stateFilePath).Reset())
+		fmt.Println("state file reset")
+		return
+	}
+
 	if *systemPath == "" || *banksArg == "" || *startStr == "" || *endStr == "" {
 		flag.Usage()
 		os.Exit(2)
 	}
 
+	rec.DefaultCurrencyCode = *defaultCurrency
+
+	var fx rec.FXTable
+	var err error
+	if *fxRatesPath != "" {
+		fx, err = rec.LoadFXTable(*fxRatesPath)
+		check(err)
+	}
+
+	var matchRules rec.MatchRules
+	if *matchRulesPath != "" {
+		matchRules, err = rec.LoadMatchRules(*matchRulesPath)
+		check(err)
+	}
+
 	sysRows, err := rec.ParseSystemCSV(*systemPath)
 	check(err)
 
-	var banks []rec.BankStatement
+	switch *bankFormat {
+	case "csv", "mt940":
+	default:
+		fmt.Fprintf(os.Stderr, "error: unknown --bank-format %q (want csv|mt940)\n", *bankFormat)
+		os.Exit(2)
+	}
+
+	var bankPaths []string
 	for _, p := range strings.Split(*banksArg, ",") {
 		p = strings.TrimSpace(p)
-		if p == "" {
-			continue
+		if p != "" {
+			bankPaths = append(bankPaths, p)
 		}
-		inferredBank := strings.TrimSuffix(filepath.Base(p), filepath.Ext(p))
-		rows, err := rec.ParseBankCSV(p, inferredBank)
-		check(err)
-		banks = append(banks, rows...)
 	}
 
+	banks, err := parseBankFilesParallel(bankPaths, *bankFormat, bankProfiles.profiles, *threads)
+	check(err)
+
 	start, err := time.Parse("2006-01-02", *startStr)
 	check(err)
 	end, err := time.Parse("2006-01-02", *endStr)
 	check(err)
 
-	res := rec.Reconcile(sysRows, banks, start, end)
+	var store *rec.StateStore
+	if *stateFilePath != "" {
+		store = rec.NewStateStore(*stateFilePath)
+		check(store.Load())
+		sysRows, banks = store.FilterKnown(sysRows, banks)
+	}
+
+	strategy := rec.Greedy
+	switch *matchStrategy {
+	case "greedy":
+	case "optimal":
+		strategy = rec.Optimal
+	default:
+		fmt.Fprintf(os.Stderr, "error: unknown --match-strategy %q (want greedy|optimal)\n", *matchStrategy)
+		os.Exit(2)
+	}
+
+	opts := rec.ReconcileOptions{
+		FX:                  fx,
+		MatchStrategy:       strategy,
+		UnmatchPenaltyCents: rec.Money(*unmatchPenaltyCents),
+		MaxDeltaCents:       rec.Money(*maxDeltaCents),
+		Rules:               matchRules,
+		Threads:             *threads,
+	}
+
+	res := rec.ReconcileParallel(context.Background(), sysRows, banks, start, end, opts)
+
+	if store != nil {
+		check(store.Commit(res))
+	}
 
 	// Shape an easy-to-consume JSON summary.
 	type sysOut struct {
@@ -65,7 +167,7 @@ func main() {
 	for _, t := range res.UnmatchedSystem {
 		unmatchedSys = append(unmatchedSys, sysOut{
 			TrxID:           t.TrxID,
-			Amount:          rec.Money(t.AmountCents).String(),
+			Amount:          t.AmountCents.Format(t.Currency()),
 			Type:            string(t.Type),
 			TransactionTime: t.TransactionTime.Format(time.RFC3339),
 		})
@@ -77,7 +179,7 @@ func main() {
 		for _, b := range arr {
 			unmatchedBank[bank] = append(unmatchedBank[bank], bankOut{
 				UniqueIdentifier: b.UniqueIdentifier,
-				Amount:           rec.Money(b.AmountCents).String(),
+				Amount:           b.AmountCents.Format(b.Currency()),
 				Date:             b.Date.Format("2006-01-02"),
 				Bank:             b.Bank,
 			})
@@ -96,7 +198,9 @@ func main() {
 		"unmatched_system":       unmatchedSys,
 		"unmatched_bank_by_name": unmatchedBank,
 
-		"total_discrepancy": rec.Money(res.TotalDiscrepancyCents).String(),
+		"total_discrepancy": res.TotalDiscrepancyCents.Format(rec.LookupCurrency(*defaultCurrency)),
+
+		"rule_match_counts": res.RuleCounts,
 	}
 
 	enc := json.NewEncoder(os.Stdout)
@@ -119,3 +223,57 @@ func check(err error) {
 		os.Exit(1)
 	}
 }
+
+// parseBankFilesParallel parses each bank file on a bounded worker pool
+// (sized threads) and concatenates the results in input order,
+// aggregating any per-file parse errors into a single error.
+func parseBankFilesParallel(paths []string, format string, profiles map[string]*rec.BankProfile, threads int) ([]rec.BankStatement, error) {
+	if threads < 1 {
+		threads = 1
+	}
+
+	type result struct {
+		rows []rec.BankStatement
+		err  error
+	}
+	results := make([]result, len(paths))
+
+	sem := make(chan struct{}, threads)
+	var wg sync.WaitGroup
+	for i, p := range paths {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, p string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			inferredBank := strings.TrimSuffix(filepath.Base(p), filepath.Ext(p))
+			var rows []rec.BankStatement
+			var err error
+			switch {
+			case format == "mt940":
+				rows, err = rec.ParseBankMT940(p, inferredBank)
+			case profiles[inferredBank] != nil:
+				rows, err = rec.ParseBankCSVWithProfile(p, profiles[inferredBank])
+			default:
+				rows, err = rec.ParseBankCSV(p, inferredBank)
+			}
+			results[i] = result{rows: rows, err: err}
+		}(i, p)
+	}
+	wg.Wait()
+
+	var banks []rec.BankStatement
+	var errs []string
+	for i, r := range results {
+		if r.err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", paths[i], r.err))
+			continue
+		}
+		banks = append(banks, r.rows...)
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("parsing %d bank file(s) failed:\n%s", len(errs), strings.Join(errs, "\n"))
+	}
+	return banks, nil
+}
@@ -0,0 +1,157 @@
+package reconcile
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrencyCode is used for rows that carry no explicit currency
+// column; callers (e.g. the CLI's --default-currency flag) may override
+// it at startup.
+var DefaultCurrencyCode = "USD"
+
+// Currency identifies an ISO 4217-ish currency code and the number of
+// minor-unit decimal places amounts are scaled to (2 for USD/EUR, 0 for
+// JPY, 3 for BHD/KWD, more for crypto).
+type Currency struct {
+	Code      string
+	Precision uint8
+}
+
+func (c Currency) scale() int64 {
+	s := int64(1)
+	for i := uint8(0); i < c.Precision; i++ {
+		s *= 10
+	}
+	return s
+}
+
+// knownCurrencies seeds the handful of non-2-decimal currencies callers
+// are most likely to hit, plus a few common 2-decimal ones; anything
+// else defaults to 2 in LookupCurrency.
+var knownCurrencies = map[string]Currency{
+	"JPY": {"JPY", 0},
+	"KRW": {"KRW", 0},
+	"BHD": {"BHD", 3},
+	"KWD": {"KWD", 3},
+	"OMR": {"OMR", 3},
+	"USD": {"USD", 2},
+	"EUR": {"EUR", 2},
+	"GBP": {"GBP", 2},
+}
+
+// LookupCurrency returns the Currency for an ISO code, defaulting to
+// 2 decimal places for codes it doesn't recognize.
+func LookupCurrency(code string) Currency {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if c, ok := knownCurrencies[code]; ok {
+		return c
+	}
+	return Currency{Code: code, Precision: 2}
+}
+
+// currencyPrecision resolves the minor-unit precision for a CSV currency
+// cell, falling back to DefaultCurrencyCode when the cell is blank —
+// mirroring Transaction.Currency/BankStatement.Currency. CSV loaders use
+// this to scale amounts at parse time instead of assuming 2 decimals.
+func currencyPrecision(code string) uint8 {
+	if code == "" {
+		code = DefaultCurrencyCode
+	}
+	return LookupCurrency(code).Precision
+}
+
+// Format renders m as a decimal string using c's precision, e.g. "12.50"
+// for {USD,2} or "1250" for {JPY,0}.
+func (m Money) Format(c Currency) string {
+	sign := ""
+	v := int64(m)
+	if v < 0 {
+		sign = "-"
+		v = -v
+	}
+	scale := c.scale()
+	whole := v / scale
+	if c.Precision == 0 {
+		return fmt.Sprintf("%s%d", sign, whole)
+	}
+	frac := v % scale
+	return fmt.Sprintf("%s%d.%0*d", sign, whole, c.Precision, frac)
+}
+
+// FXTable holds conversion rates between currency pairs, keyed
+// "FROM/TO" (e.g. "EUR/USD" means 1 EUR = rate USD).
+type FXTable map[string]float64
+
+// Rate returns the multiplier to convert an amount in `from` into `to`.
+// Same-currency pairs always convert at 1; a reverse-looked-up rate is
+// inverted when only the opposite direction is on file.
+func (fx FXTable) Rate(from, to string) (float64, bool) {
+	from, to = strings.ToUpper(from), strings.ToUpper(to)
+	if from == to {
+		return 1, true
+	}
+	if fx == nil {
+		return 0, false
+	}
+	if r, ok := fx[from+"/"+to]; ok {
+		return r, true
+	}
+	if r, ok := fx[to+"/"+from]; ok && r != 0 {
+		return 1 / r, true
+	}
+	return 0, false
+}
+
+// LoadFXTable reads a CSV of "from,to,rate" rows into an FXTable.
+func LoadFXTable(path string) (FXTable, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+
+	head, err := r.Read()
+	if err != nil {
+		return nil, err
+	}
+	idx := func(name string) int {
+		for i, h := range head {
+			if strings.EqualFold(strings.TrimSpace(h), name) {
+				return i
+			}
+		}
+		return -1
+	}
+	iFrom, iTo, iRate := idx("from"), idx("to"), idx("rate")
+	if iFrom < 0 || iTo < 0 || iRate < 0 {
+		return nil, fmt.Errorf("missing required headers in FX rates CSV (from,to,rate)")
+	}
+
+	fx := make(FXTable)
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		rate, err := strconv.ParseFloat(strings.TrimSpace(rec[iRate]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("rate parse: %w", err)
+		}
+		from := strings.ToUpper(strings.TrimSpace(rec[iFrom]))
+		to := strings.ToUpper(strings.TrimSpace(rec[iTo]))
+		fx[from+"/"+to] = rate
+	}
+
+	return fx, nil
+}
@@ -0,0 +1,79 @@
+package reconcile
+
+// hungarianMinCost solves the minimum-cost perfect matching on a square
+// n x n cost matrix using the Kuhn-Munkres (Hungarian) algorithm in
+// O(n^3): it maintains row/column potentials and repeatedly finds an
+// augmenting path via a Dijkstra-like relaxation, rather than literally
+// drawing cover-lines over zeros (an equivalent, easier-to-implement
+// formulation of the same textbook algorithm). Returns assignment where
+// assignment[i] is the column matched to row i.
+func hungarianMinCost(cost [][]int64) []int {
+	n := len(cost)
+	if n == 0 {
+		return nil
+	}
+
+	const inf = int64(1) << 60
+
+	u := make([]int64, n+1)
+	v := make([]int64, n+1)
+	p := make([]int, n+1) // p[j] = row assigned to column j, 1-indexed; 0 = unassigned
+	way := make([]int, n+1)
+
+	for i := 1; i <= n; i++ {
+		p[0] = i
+		j0 := 0
+		minv := make([]int64, n+1)
+		used := make([]bool, n+1)
+		for j := 0; j <= n; j++ {
+			minv[j] = inf
+		}
+
+		for {
+			used[j0] = true
+			i0 := p[j0]
+			delta := inf
+			j1 := -1
+			for j := 1; j <= n; j++ {
+				if used[j] {
+					continue
+				}
+				cur := cost[i0-1][j-1] - u[i0] - v[j]
+				if cur < minv[j] {
+					minv[j] = cur
+					way[j] = j0
+				}
+				if minv[j] < delta {
+					delta = minv[j]
+					j1 = j
+				}
+			}
+			for j := 0; j <= n; j++ {
+				if used[j] {
+					u[p[j]] += delta
+					v[j] -= delta
+				} else {
+					minv[j] -= delta
+				}
+			}
+			j0 = j1
+			if p[j0] == 0 {
+				break
+			}
+		}
+
+		for j0 != 0 {
+			j1 := way[j0]
+			p[j0] = p[j1]
+			j0 = j1
+		}
+	}
+
+	assignment := make([]int, n)
+	for j := 1; j <= n; j++ {
+		if p[j] != 0 {
+			assignment[p[j]-1] = j - 1
+		}
+	}
+	return assignment
+}
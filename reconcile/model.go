@@ -40,6 +40,17 @@ type Transaction struct {
 	AmountCents     Money
 	Type            TxType
 	TransactionTime time.Time
+	CurrencyCode    string // ISO code; defaults to DefaultCurrencyCode when empty
+	Description     string // free text; optional, used by MatchRule predicates
+}
+
+// Currency resolves the transaction's Currency, defaulting to
+// DefaultCurrencyCode (USD unless overridden) when CurrencyCode is unset.
+func (t Transaction) Currency() Currency {
+	if t.CurrencyCode == "" {
+		return LookupCurrency(DefaultCurrencyCode)
+	}
+	return LookupCurrency(t.CurrencyCode)
 }
 
 func (t Transaction) SignedAmount() Money {
@@ -61,6 +72,17 @@ type BankStatement struct {
 	AmountCents      Money // can be negative for debits
 	Date             time.Time
 	Bank             string
+	CurrencyCode     string // ISO code; defaults to DefaultCurrencyCode when empty
+	Description      string // free text; optional, used by MatchRule predicates
+}
+
+// Currency resolves the statement row's Currency, defaulting to
+// DefaultCurrencyCode (USD unless overridden) when CurrencyCode is unset.
+func (b BankStatement) Currency() Currency {
+	if b.CurrencyCode == "" {
+		return LookupCurrency(DefaultCurrencyCode)
+	}
+	return LookupCurrency(b.CurrencyCode)
 }
 
 // -------- CSV loaders (simple & explicit) --------
@@ -93,6 +115,8 @@ func ParseSystemCSV(path string) ([]Transaction, error) {
 	iAmt := idx("amount")
 	iType := idx("type")
 	iTime := idx("transactionTime")
+	iCur := idx("currency")
+	iDesc := idx("description")
 	if iTrx < 0 || iAmt < 0 || iType < 0 || iTime < 0 {
 		return nil, errors.New("missing required headers in system CSV (trxID,amount,type,transactionTime)")
 	}
@@ -107,7 +131,12 @@ func ParseSystemCSV(path string) ([]Transaction, error) {
 			return nil, err
 		}
 
-		amt, err := parseDecimalToCents(rec[iAmt])
+		currency := ""
+		if iCur >= 0 {
+			currency = strings.TrimSpace(rec[iCur])
+		}
+
+		amt, err := parseDecimalToCents(rec[iAmt], currencyPrecision(currency))
 		if err != nil {
 			return nil, fmt.Errorf("amount parse: %w", err)
 		}
@@ -122,89 +151,62 @@ func ParseSystemCSV(path string) ([]Transaction, error) {
 			return nil, fmt.Errorf("transactionTime parse (RFC3339): %w", err)
 		}
 
+		description := ""
+		if iDesc >= 0 {
+			description = strings.TrimSpace(rec[iDesc])
+		}
+
 		out = append(out, Transaction{
 			TrxID:           strings.TrimSpace(rec[iTrx]),
 			AmountCents:     Money(amt),
 			Type:            TxType(typeStr),
 			TransactionTime: ts,
+			CurrencyCode:    currency,
+			Description:     description,
 		})
 	}
 
 	return out, nil
 }
 
+// ParseBankCSV parses a bank CSV export using the original fixed column
+// layout (unique_identifier,amount,date[,bank]). It is a thin wrapper
+// around ParseBankCSVWithProfile using DefaultBankProfile; onboard a
+// bank with a different column layout via BankProfile instead.
 func ParseBankCSV(path string, bankName string) ([]BankStatement, error) {
-	f, err := os.Open(path)
-	if err != nil {
-		return nil, err
-	}
-	defer f.Close()
+	profile := DefaultBankProfile()
+	profile.DefaultBank = bankName
+	return ParseBankCSVWithProfile(path, profile)
+}
 
+// readCSV reads a CSV file's header row and all remaining records.
+func readCSV(f io.Reader) (head []string, rows [][]string, err error) {
 	r := csv.NewReader(f)
 	r.FieldsPerRecord = -1
 
-	head, err := r.Read()
+	head, err = r.Read()
 	if err != nil {
-		return nil, err
-	}
-
-	idx := func(name string) int {
-		for i, h := range head {
-			if strings.EqualFold(strings.TrimSpace(h), name) {
-				return i
-			}
-		}
-		return -1
+		return nil, nil, err
 	}
 
-	iUID := idx("unique_identifier")
-	iAmt := idx("amount")
-	iDate := idx("date")
-	iBank := idx("bank")
-	if iUID < 0 || iAmt < 0 || iDate < 0 {
-		return nil, errors.New("missing required headers in bank CSV (unique_identifier,amount,date[,bank])")
-	}
-
-	var out []BankStatement
 	for {
 		rec, err := r.Read()
 		if err == io.EOF {
 			break
 		}
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
-
-		amt, err := parseDecimalToCents(rec[iAmt])
-		if err != nil {
-			return nil, fmt.Errorf("amount parse: %w", err)
-		}
-		d, err := time.Parse("2006-01-02", strings.TrimSpace(rec[iDate]))
-		if err != nil {
-			return nil, fmt.Errorf("date parse (YYYY-MM-DD): %w", err)
-		}
-
-		b := strings.TrimSpace(bankName)
-		if iBank >= 0 && strings.TrimSpace(rec[iBank]) != "" {
-			b = strings.TrimSpace(rec[iBank])
-		}
-		if b == "" {
-			b = "UNKNOWN"
-		}
-
-		out = append(out, BankStatement{
-			UniqueIdentifier: strings.TrimSpace(rec[iUID]),
-			AmountCents:      Money(amt),
-			Date:             d,
-			Bank:             b,
-		})
+		rows = append(rows, rec)
 	}
 
-	return out, nil
+	return head, rows, nil
 }
 
-// parseDecimalToCents parses "-100.25" into -10025 (cents). Truncates extra precision.
-func parseDecimalToCents(s string) (int64, error) {
+// parseDecimalToCents parses "-100.25" into -10025 minor units at
+// precision 2, or "30" into 30 at precision 0 for JPY-like currencies.
+// Extra fractional digits beyond precision are truncated.
+func parseDecimalToCents(s string, precision uint8) (int64, error) {
 	s = strings.TrimSpace(s)
 	if s == "" {
 		return 0, errors.New("empty amount")
@@ -217,31 +219,33 @@ func parseDecimalToCents(s string) (int64, error) {
 		s = s[1:]
 	}
 
-	parts := strings.SplitN(s, ".", 3)
-	if len(parts) == 1 {
-		v, err := strconv.ParseInt(parts[0], 10, 64)
-		if err != nil {
-			return 0, err
-		}
-		return sign * v * 100, nil
+	parts := strings.SplitN(s, ".", 2)
+	whole := parts[0]
+	frac := ""
+	if len(parts) == 2 {
+		frac = parts[1]
 	}
-
-	dollars := parts[0]
-	cents := parts[1]
-	if len(cents) > 2 {
-		cents = cents[:2] // truncate while loading
+	if len(frac) > int(precision) {
+		frac = frac[:precision] // truncate while loading
 	}
-	for len(cents) < 2 {
-		cents += "0"
+	for len(frac) < int(precision) {
+		frac += "0"
 	}
-	vd, err := strconv.ParseInt(dollars, 10, 64)
+
+	vw, err := strconv.ParseInt(whole, 10, 64)
 	if err != nil {
 		return 0, err
 	}
-	vc, err := strconv.ParseInt(cents, 10, 64)
+	scale := int64(1)
+	for i := uint8(0); i < precision; i++ {
+		scale *= 10
+	}
+	if frac == "" {
+		return sign * vw * scale, nil
+	}
+	vf, err := strconv.ParseInt(frac, 10, 64)
 	if err != nil {
 		return 0, err
 	}
-
-	return sign * (vd*100 + vc), nil
+	return sign * (vw*scale + vf), nil
 }
@@ -0,0 +1,115 @@
+package reconcile
+
+import (
+	"context"
+	"sync"
+	timepkg "time"
+)
+
+// ReconcileParallel is Reconcile's concurrent counterpart: it windows
+// and buckets rows the same way, but matches each (date, sign) bucket
+// on its own goroutine, bounded by a semaphore sized opts.Threads
+// (defaulting to runtime.NumCPU()), since buckets are independent of
+// one another. ctx lets a caller cancel a long-running reconciliation;
+// buckets not yet started when ctx is done are folded into the
+// unmatched sets untouched rather than matched. UnmatchedSystem's
+// ordering is identical to Reconcile's regardless of goroutine
+// scheduling, since both sort it by TransactionTime then TrxID before
+// return; MatchedPairs and the per-bank unmatched lists follow map
+// iteration order in both.
+//
+// ReconcileOptions.Rules matching is an ordered, single-pass algorithm
+// over system rows rather than independent buckets, so there is nothing
+// to parallelize there; ReconcileParallel falls back to the sequential
+// rule-based path in that case.
+func ReconcileParallel(ctx context.Context, system []Transaction, bank []BankStatement, start, end timepkg.Time, opts ReconcileOptions) ReconciliationResult {
+	if len(opts.Rules) > 0 {
+		return reconcileWithRules(system, bank, start, end, opts)
+	}
+
+	sysIn, bankIn := filterWindow(system, bank, start, end)
+	sysBuckets, bankBuckets, keys := bucketRows(sysIn, bankIn)
+
+	keyList := make([]bucketKey, 0, len(keys))
+	for k := range keys {
+		keyList = append(keyList, k)
+	}
+
+	type bucketResult struct {
+		pairs         []MatchedPair
+		unmatchedSys  []Transaction
+		unmatchedBank []BankStatement
+	}
+	results := make([]bucketResult, len(keyList))
+
+	sem := make(chan struct{}, opts.threads())
+	var wg sync.WaitGroup
+	for i, k := range keyList {
+		sysList := sysBuckets[k]
+		bankList := bankBuckets[k]
+
+		if ctx.Err() != nil {
+			// Past the cancellation point: leave this bucket's rows
+			// exactly as unmatched rather than starting new work.
+			results[i] = bucketResult{unmatchedSys: sysList, unmatchedBank: bankList}
+			continue
+		}
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, sysList []Transaction, bankList []BankStatement) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			if ctx.Err() != nil {
+				// Cancelled while waiting for a semaphore slot.
+				results[i] = bucketResult{unmatchedSys: sysList, unmatchedBank: bankList}
+				return
+			}
+
+			strategy := opts.MatchStrategy
+			if strategy == Optimal && (len(sysList) > opts.maxBucketSize() || len(bankList) > opts.maxBucketSize()) {
+				strategy = Greedy
+			}
+
+			var r bucketResult
+			if strategy == Optimal {
+				r.pairs, r.unmatchedSys, r.unmatchedBank = reconcileBucketOptimal(sysList, bankList, opts)
+			} else {
+				r.pairs, r.unmatchedSys, r.unmatchedBank = reconcileBucketGreedy(sysList, bankList, opts.FX)
+			}
+			results[i] = r
+		}(i, sysList, bankList)
+	}
+	wg.Wait()
+
+	matched := 0
+	var discrepancy Money
+	var pairs []MatchedPair
+	var unmatchedSys []Transaction
+	unmatchedBank := make(map[string][]BankStatement)
+	for _, r := range results {
+		matched += len(r.pairs)
+		pairs = append(pairs, r.pairs...)
+		for _, p := range r.pairs {
+			discrepancy += p.DiscrepancyCents
+		}
+		unmatchedSys = append(unmatchedSys, r.unmatchedSys...)
+		for _, b := range r.unmatchedBank {
+			unmatchedBank[b.Bank] = append(unmatchedBank[b.Bank], b)
+		}
+	}
+
+	sortByTime(unmatchedSys)
+
+	return ReconciliationResult{
+		TotalSystemTransactions: len(sysIn),
+		TotalBankTransactions:   len(bankIn),
+		TotalProcessed:          len(sysIn) + len(bankIn),
+		MatchedCount:            matched,
+		MatchedPairs:            pairs,
+		UnmatchedSystem:         unmatchedSys,
+		UnmatchedBankByName:     unmatchedBank,
+		TotalDiscrepancyCents:   discrepancy,
+	}
+}
@@ -0,0 +1,280 @@
+package reconcile
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// BankProfile declares how to read one bank's CSV export: which column
+// header(s) map to each required field, how dates and decimals are
+// formatted, and (optionally) how the sign of an amount is encoded and
+// how to derive UniqueIdentifier from free text. This lets a new
+// institution be onboarded by dropping in a profile file instead of
+// recompiling.
+type BankProfile struct {
+	Name string `json:"name"`
+
+	// ColumnAliases maps a field to the list of header names a CSV export
+	// may use for it. "unique_identifier", "amount" and "date" are
+	// required; "bank", "currency" and "description" are optional. The
+	// first alias found in the file's header row wins.
+	ColumnAliases map[string][]string `json:"column_aliases"`
+
+	// DateLayout is a Go reference-time layout, e.g. "02.01.2006".
+	// Defaults to "2006-01-02" when empty.
+	DateLayout string `json:"date_layout"`
+
+	// DecimalSeparator is "." or ",". Defaults to "." when empty.
+	DecimalSeparator string `json:"decimal_separator"`
+	// ThousandsSeparator, if set, is stripped from amounts before parsing.
+	ThousandsSeparator string `json:"thousands_separator"`
+
+	// SignColumn, if set, names a column whose value decides the sign of
+	// the amount instead of a leading "-" in the amount column itself.
+	SignColumn   string   `json:"sign_column"`
+	DebitTokens  []string `json:"debit_tokens"`
+	CreditTokens []string `json:"credit_tokens"`
+
+	// UniqueIdentifierRegex, if set, is applied to UniqueIdentifierColumn
+	// (or the unique_identifier column itself when empty) and the first
+	// capture group becomes UniqueIdentifier. Useful when the reference
+	// is embedded in a free-text description.
+	UniqueIdentifierRegex  string `json:"unique_identifier_regex"`
+	UniqueIdentifierColumn string `json:"unique_identifier_source_column"`
+
+	// DefaultBank is used when the file has no bank column (or the cell
+	// is blank), mirroring the bankName fallback ParseBankCSV always had.
+	DefaultBank string `json:"default_bank"`
+
+	// DefaultCurrency is used when the file has no currency column (or
+	// the cell is blank); falls back further to DefaultCurrencyCode.
+	DefaultCurrency string `json:"default_currency"`
+}
+
+// DefaultBankProfile matches the column layout ParseBankCSV has always
+// used: unique_identifier,amount,date[,bank].
+func DefaultBankProfile() *BankProfile {
+	return &BankProfile{
+		ColumnAliases: map[string][]string{
+			"unique_identifier": {"unique_identifier"},
+			"amount":            {"amount"},
+			"date":              {"date"},
+			"bank":              {"bank"},
+			"currency":          {"currency"},
+			"description":       {"description"},
+		},
+	}
+}
+
+// LoadBankProfile reads a BankProfile from a JSON config file.
+func LoadBankProfile(path string) (*BankProfile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var p BankProfile
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("bank profile %s: %w", path, err)
+	}
+	if len(p.ColumnAliases) == 0 {
+		return nil, fmt.Errorf("bank profile %s: column_aliases is required", path)
+	}
+	return &p, nil
+}
+
+func (p *BankProfile) dateLayout() string {
+	if p.DateLayout != "" {
+		return p.DateLayout
+	}
+	return "2006-01-02"
+}
+
+func (p *BankProfile) findColumn(head []string, field string) int {
+	for _, alias := range p.ColumnAliases[field] {
+		for i, h := range head {
+			if strings.EqualFold(strings.TrimSpace(h), alias) {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// columnByHeader finds a column by its literal header text, for profile
+// fields (SignColumn, UniqueIdentifierColumn) that name a header
+// directly instead of going through ColumnAliases.
+func columnByHeader(head []string, name string) int {
+	for i, h := range head {
+		if strings.EqualFold(strings.TrimSpace(h), name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// parseAmount converts a raw field value to minor units at the given
+// currency precision, honoring the profile's decimal/thousands
+// separators and optional sign column.
+func (p *BankProfile) parseAmount(raw string, signField string, precision uint8) (int64, error) {
+	s := strings.TrimSpace(raw)
+	if p.ThousandsSeparator != "" {
+		s = strings.ReplaceAll(s, p.ThousandsSeparator, "")
+	}
+	if p.DecimalSeparator != "" && p.DecimalSeparator != "." {
+		s = strings.ReplaceAll(s, p.DecimalSeparator, ".")
+	}
+
+	cents, err := parseDecimalToCents(s, precision)
+	if err != nil {
+		return 0, err
+	}
+
+	if p.SignColumn != "" {
+		negative, err := p.signFromToken(signField)
+		if err != nil {
+			return 0, err
+		}
+		if cents < 0 {
+			cents = -cents // amount column shouldn't also carry a sign, but normalize just in case
+		}
+		if negative {
+			cents = -cents
+		}
+	}
+
+	return cents, nil
+}
+
+func (p *BankProfile) signFromToken(token string) (negative bool, err error) {
+	token = strings.TrimSpace(token)
+	for _, t := range p.DebitTokens {
+		if strings.EqualFold(t, token) {
+			return true, nil
+		}
+	}
+	for _, t := range p.CreditTokens {
+		if strings.EqualFold(t, token) {
+			return false, nil
+		}
+	}
+	return false, fmt.Errorf("sign column value %q matches neither debit_tokens nor credit_tokens", token)
+}
+
+// ParseBankCSVWithProfile parses a bank CSV export using a BankProfile
+// describing its column layout and formatting conventions.
+func ParseBankCSVWithProfile(path string, profile *BankProfile) ([]BankStatement, error) {
+	if profile == nil {
+		profile = DefaultBankProfile()
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	head, rows, err := readCSV(f)
+	if err != nil {
+		return nil, err
+	}
+
+	iUID := profile.findColumn(head, "unique_identifier")
+	iAmt := profile.findColumn(head, "amount")
+	iDate := profile.findColumn(head, "date")
+	iBank := profile.findColumn(head, "bank")
+	iCur := profile.findColumn(head, "currency")
+	iDesc := profile.findColumn(head, "description")
+	if iUID < 0 || iAmt < 0 || iDate < 0 {
+		return nil, errors.New("missing required columns in bank CSV for this profile (unique_identifier, amount, date)")
+	}
+
+	iSign := -1
+	if profile.SignColumn != "" {
+		iSign = columnByHeader(head, profile.SignColumn)
+		if iSign < 0 {
+			return nil, fmt.Errorf("sign column %q not found in header", profile.SignColumn)
+		}
+	}
+
+	iUIDSource := iUID
+	var uidRe *regexp.Regexp
+	if profile.UniqueIdentifierRegex != "" {
+		uidRe, err = regexp.Compile(profile.UniqueIdentifierRegex)
+		if err != nil {
+			return nil, fmt.Errorf("unique_identifier_regex: %w", err)
+		}
+		if profile.UniqueIdentifierColumn != "" {
+			if i := columnByHeader(head, profile.UniqueIdentifierColumn); i >= 0 {
+				iUIDSource = i
+			}
+		}
+	}
+
+	var out []BankStatement
+	for _, rec := range rows {
+		signField := ""
+		if iSign >= 0 {
+			signField = rec[iSign]
+		}
+
+		currency := ""
+		if iCur >= 0 {
+			currency = strings.TrimSpace(rec[iCur])
+		}
+		if currency == "" {
+			currency = profile.DefaultCurrency
+		}
+
+		amt, err := profile.parseAmount(rec[iAmt], signField, currencyPrecision(currency))
+		if err != nil {
+			return nil, fmt.Errorf("amount parse: %w", err)
+		}
+
+		d, err := time.Parse(profile.dateLayout(), strings.TrimSpace(rec[iDate]))
+		if err != nil {
+			return nil, fmt.Errorf("date parse (%s): %w", profile.dateLayout(), err)
+		}
+
+		uid := strings.TrimSpace(rec[iUID])
+		if uidRe != nil {
+			m := uidRe.FindStringSubmatch(rec[iUIDSource])
+			if len(m) > 1 {
+				uid = m[1]
+			} else if len(m) == 1 {
+				uid = m[0]
+			}
+		}
+
+		b := ""
+		if iBank >= 0 {
+			b = strings.TrimSpace(rec[iBank])
+		}
+		if b == "" {
+			b = profile.DefaultBank
+		}
+		if b == "" {
+			b = "UNKNOWN"
+		}
+
+		description := ""
+		if iDesc >= 0 {
+			description = strings.TrimSpace(rec[iDesc])
+		}
+
+		out = append(out, BankStatement{
+			UniqueIdentifier: uid,
+			AmountCents:      Money(amt),
+			Date:             d,
+			Bank:             b,
+			CurrencyCode:     currency,
+			Description:      description,
+		})
+	}
+
+	return out, nil
+}
@@ -1,10 +1,106 @@
 package reconcile
 
 import (
+	"math"
+	"runtime"
 	"sort"
 	timepkg "time"
 )
 
+// MatchStrategy selects how candidates within a (date, sign) bucket are
+// paired off.
+type MatchStrategy int
+
+const (
+	// Greedy picks, for each system row in arrival order, the nearest
+	// remaining bank row. Fast but not globally optimal.
+	Greedy MatchStrategy = iota
+	// Optimal solves each bucket as a minimum-cost bipartite assignment
+	// (Hungarian algorithm), minimizing the total discrepancy across all
+	// pairs in the bucket rather than each pair individually.
+	Optimal
+)
+
+// defaultUnmatchPenaltyCents is deliberately far larger than any
+// plausible real-world amount delta, so Optimal only leaves a pair
+// unmatched when MaxDeltaCents says the delta is too large to trust or
+// the two rows' currencies have no FX route.
+const defaultUnmatchPenaltyCents Money = 1 << 40
+
+// defaultMaxBucketSize caps Optimal's O(n^3) cost; larger buckets fall
+// back to Greedy automatically.
+const defaultMaxBucketSize = 500
+
+// ReconcileOptions configures Reconcile's matching behavior.
+type ReconcileOptions struct {
+	// FX, if non-nil, allows matching bank rows whose currency differs
+	// from the system row's by converting the bank amount first.
+	FX FXTable
+
+	MatchStrategy MatchStrategy
+
+	// UnmatchPenaltyCents is Optimal's cost for leaving a row unmatched.
+	// Defaults to defaultUnmatchPenaltyCents when zero.
+	UnmatchPenaltyCents Money
+	// MaxDeltaCents, if positive, makes Optimal reject any pair whose
+	// amount delta exceeds it. Greedy does not consult it. Zero means no
+	// cap.
+	MaxDeltaCents Money
+	// MaxBucketSize caps the larger side of a bucket before Optimal
+	// falls back to Greedy. Defaults to defaultMaxBucketSize when zero.
+	MaxBucketSize int
+
+	// Rules, if non-empty, replaces the (date, sign) bucketing and
+	// MatchStrategy entirely with rule-based matching (see
+	// reconcileWithRules).
+	Rules MatchRules
+
+	// Threads bounds how many buckets ReconcileParallel matches
+	// concurrently. Defaults to runtime.NumCPU() when zero. Unused by
+	// Reconcile, which is always single-threaded.
+	Threads int
+}
+
+func (o ReconcileOptions) unmatchPenalty() Money {
+	if o.UnmatchPenaltyCents > 0 {
+		return o.UnmatchPenaltyCents
+	}
+	return defaultUnmatchPenaltyCents
+}
+
+func (o ReconcileOptions) maxBucketSize() int {
+	if o.MaxBucketSize > 0 {
+		return o.MaxBucketSize
+	}
+	return defaultMaxBucketSize
+}
+
+func (o ReconcileOptions) threads() int {
+	if o.Threads > 0 {
+		return o.Threads
+	}
+	return runtime.NumCPU()
+}
+
+// MatchedPair is one system transaction paired with the bank statement
+// row it was reconciled against.
+type MatchedPair struct {
+	System Transaction
+	Bank   BankStatement
+
+	// DiscrepancyCents is the absolute amount delta, expressed in the
+	// system transaction's currency minor units.
+	DiscrepancyCents Money
+
+	// AppliedFXRate is the rate used to convert Bank's amount into
+	// System's currency (1 when both rows share a currency).
+	AppliedFXRate float64
+
+	// Reason names the MatchRule that produced this pair (see
+	// reconcileWithRules). Empty when ReconcileOptions.Rules was unused.
+	Reason string
+}
+
 // ReconciliationResult summarizes the output.
 type ReconciliationResult struct {
 	TotalSystemTransactions int
@@ -12,21 +108,97 @@ type ReconciliationResult struct {
 	TotalProcessed          int
 
 	MatchedCount int
+	MatchedPairs []MatchedPair
 
 	UnmatchedSystem     []Transaction
 	UnmatchedBankByName map[string][]BankStatement
 
-	// Sum of absolute differences across matched pairs (in cents).
+	// Sum of absolute differences across matched pairs, each expressed
+	// in its own pair's system-side currency minor units (mixing
+	// currencies here is an existing caveat of a single running total).
 	TotalDiscrepancyCents Money
+
+	// RuleCounts tallies matches per MatchRule.Name (see reconcileWithRules).
+	// Nil when ReconcileOptions.Rules was unused.
+	RuleCounts map[string]int
+}
+
+// bucketKey groups rows by transaction DATE and SIGN (+/-); currency
+// compatibility is handled per-pair via FX rather than as part of the
+// key, so a bucket may mix currencies.
+type bucketKey struct {
+	dateUnix int64
+	sign     int
 }
 
-// Reconcile matches system vs bank rows within [start,end] (inclusive),
-// using transaction DATE and SIGN (+/-) as the match key.
-// Among candidates in the same bucket (date+sign), the nearest amount is chosen.
-// Any leftovers are reported as unmatched (system) or unmatched-by-bank (bank).
-func Reconcile(system []Transaction, bank []BankStatement, start, end timepkg.Time) ReconciliationResult {
-	// Filter by inclusive date window.
-	sysIn := make([]Transaction, 0, len(system))
+// Reconcile matches system vs bank rows within [start,end] (inclusive).
+// Rows are grouped into buckets by DATE and SIGN; within each bucket,
+// opts.MatchStrategy picks candidates (see Greedy and Optimal). A
+// system and bank row in different currencies is only a candidate pair
+// when opts.FX carries a usable rate between them. Any leftovers are
+// reported as unmatched (system) or unmatched-by-bank (bank).
+func Reconcile(system []Transaction, bank []BankStatement, start, end timepkg.Time, opts ReconcileOptions) ReconciliationResult {
+	if len(opts.Rules) > 0 {
+		return reconcileWithRules(system, bank, start, end, opts)
+	}
+
+	sysIn, bankIn := filterWindow(system, bank, start, end)
+	sysBuckets, bankBuckets, keys := bucketRows(sysIn, bankIn)
+
+	matched := 0
+	var discrepancy Money
+	var pairs []MatchedPair
+	var unmatchedSys []Transaction
+	unmatchedBank := make(map[string][]BankStatement)
+
+	for k := range keys {
+		sysList := sysBuckets[k]
+		bankList := bankBuckets[k]
+
+		strategy := opts.MatchStrategy
+		if strategy == Optimal && (len(sysList) > opts.maxBucketSize() || len(bankList) > opts.maxBucketSize()) {
+			strategy = Greedy
+		}
+
+		var bucketPairs []MatchedPair
+		var bucketUnmatchedSys []Transaction
+		var bucketUnmatchedBank []BankStatement
+		if strategy == Optimal {
+			bucketPairs, bucketUnmatchedSys, bucketUnmatchedBank = reconcileBucketOptimal(sysList, bankList, opts)
+		} else {
+			bucketPairs, bucketUnmatchedSys, bucketUnmatchedBank = reconcileBucketGreedy(sysList, bankList, opts.FX)
+		}
+
+		matched += len(bucketPairs)
+		pairs = append(pairs, bucketPairs...)
+		for _, p := range bucketPairs {
+			discrepancy += p.DiscrepancyCents
+		}
+		unmatchedSys = append(unmatchedSys, bucketUnmatchedSys...)
+		for _, b := range bucketUnmatchedBank {
+			unmatchedBank[b.Bank] = append(unmatchedBank[b.Bank], b)
+		}
+	}
+
+	sortByTime(unmatchedSys)
+
+	return ReconciliationResult{
+		TotalSystemTransactions: len(sysIn),
+		TotalBankTransactions:   len(bankIn),
+		TotalProcessed:          len(sysIn) + len(bankIn),
+		MatchedCount:            matched,
+		MatchedPairs:            pairs,
+		UnmatchedSystem:         unmatchedSys,
+		UnmatchedBankByName:     unmatchedBank,
+		TotalDiscrepancyCents:   discrepancy,
+	}
+}
+
+// filterWindow returns the subset of system and bank rows whose date
+// falls within [start, end] (inclusive), shared by Reconcile and
+// ReconcileParallel.
+func filterWindow(system []Transaction, bank []BankStatement, start, end timepkg.Time) (sysIn []Transaction, bankIn []BankStatement) {
+	sysIn = make([]Transaction, 0, len(system))
 	for _, t := range system {
 		td := t.DateOnly()
 		if !td.Before(start) && !td.After(end) {
@@ -34,88 +206,204 @@ func Reconcile(system []Transaction, bank []BankStatement, start, end timepkg.Ti
 		}
 	}
 
-	bankIn := make([]BankStatement, 0, len(bank))
+	bankIn = make([]BankStatement, 0, len(bank))
 	for _, b := range bank {
 		if !b.Date.Before(start) && !b.Date.After(end) {
 			bankIn = append(bankIn, b)
 		}
 	}
+	return sysIn, bankIn
+}
 
-	// Buckets keyed by (date, sign).
-	type key struct {
-		dateUnix int64
-		sign     int // +1 for >=0, -1 for <0
+// bucketRows groups already-windowed rows by bucketKey, shared by
+// Reconcile and ReconcileParallel.
+func bucketRows(sysIn []Transaction, bankIn []BankStatement) (sysBuckets map[bucketKey][]Transaction, bankBuckets map[bucketKey][]BankStatement, keys map[bucketKey]bool) {
+	sysBuckets = make(map[bucketKey][]Transaction)
+	for _, t := range sysIn {
+		k := bucketKey{dateUnix: t.DateOnly().Unix(), sign: signOf(t.SignedAmount())}
+		sysBuckets[k] = append(sysBuckets[k], t)
 	}
 
-	buckets := make(map[key][]BankStatement)
+	bankBuckets = make(map[bucketKey][]BankStatement)
 	for _, b := range bankIn {
-		sign := 1
-		if b.AmountCents < 0 {
-			sign = -1
-		}
-		k := key{dateUnix: b.Date.Unix(), sign: sign}
-		buckets[k] = append(buckets[k], b)
+		k := bucketKey{dateUnix: b.Date.Unix(), sign: signOf(b.AmountCents)}
+		bankBuckets[k] = append(bankBuckets[k], b)
+	}
+
+	keys = make(map[bucketKey]bool)
+	for k := range sysBuckets {
+		keys[k] = true
 	}
+	for k := range bankBuckets {
+		keys[k] = true
+	}
+	return sysBuckets, bankBuckets, keys
+}
 
-	// Deterministic system iteration (by transaction time).
-	sort.SliceStable(sysIn, func(i, j int) bool {
-		return sysIn[i].TransactionTime.Before(sysIn[j].TransactionTime)
+func signOf(m Money) int {
+	if m < 0 {
+		return -1
+	}
+	return 1
+}
+
+// sortByTime orders transactions by TransactionTime, then by TrxID to
+// break ties, so output (and any downstream diffing of it, or of
+// ReconcileParallel's goroutine-scheduling-dependent results) doesn't
+// depend on input or bucket-processing order.
+func sortByTime(txs []Transaction) {
+	sort.SliceStable(txs, func(i, j int) bool {
+		if !txs[i].TransactionTime.Equal(txs[j].TransactionTime) {
+			return txs[i].TransactionTime.Before(txs[j].TransactionTime)
+		}
+		return txs[i].TrxID < txs[j].TrxID
 	})
+}
 
-	matched := 0
-	var discrepancy Money
-	var unmatchedSys []Transaction
+// pairDelta reports the absolute amount delta between tx and b,
+// converting b's amount into tx's currency via fx when they differ. ok
+// is false when the currencies differ and fx has no usable rate.
+func pairDelta(tx Transaction, b BankStatement, fx FXTable) (delta Money, rate float64, ok bool) {
+	sysCur := tx.Currency()
+	bankCur := b.Currency()
 
-	for _, tx := range sysIn {
-		sign := 1
-		if tx.SignedAmount() < 0 {
-			sign = -1
+	if bankCur.Code == sysCur.Code {
+		rate = 1
+	} else {
+		r, found := fx.Rate(bankCur.Code, sysCur.Code)
+		if !found {
+			return 0, 0, false
+		}
+		rate = r
+	}
+
+	converted := convertAmount(b.AmountCents, bankCur, sysCur, rate)
+	delta = tx.SignedAmount() - converted
+	if delta < 0 {
+		delta = -delta
+	}
+	return delta, rate, true
+}
+
+// reconcileBucketGreedy picks, for each system row in time order, the
+// nearest remaining bank row (the original, pre-Optimal matching rule).
+func reconcileBucketGreedy(sysList []Transaction, bankList []BankStatement, fx FXTable) (pairs []MatchedPair, unmatchedSys []Transaction, unmatchedBank []BankStatement) {
+	sys := append([]Transaction(nil), sysList...)
+	sortByTime(sys)
+
+	used := make([]bool, len(bankList))
+
+	for _, tx := range sys {
+		bestIdx := -1
+		var bestDelta Money
+		var bestRate float64
+		for i, b := range bankList {
+			if used[i] {
+				continue
+			}
+			delta, rate, ok := pairDelta(tx, b, fx)
+			if !ok {
+				continue
+			}
+			if bestIdx == -1 || delta < bestDelta {
+				bestIdx, bestDelta, bestRate = i, delta, rate
+			}
 		}
-		k := key{dateUnix: tx.DateOnly().Unix(), sign: sign}
 
-		candidates := buckets[k]
-		if len(candidates) == 0 {
+		if bestIdx == -1 {
 			unmatchedSys = append(unmatchedSys, tx)
 			continue
 		}
+		used[bestIdx] = true
+		pairs = append(pairs, MatchedPair{
+			System:           tx,
+			Bank:             bankList[bestIdx],
+			DiscrepancyCents: bestDelta,
+			AppliedFXRate:    bestRate,
+		})
+	}
 
-		// Pick nearest amount (absolute delta).
-		target := tx.SignedAmount()
-		bestIdx := -1
-		var bestDiff Money
-		for i, c := range candidates {
-			diff := target - c.AmountCents
-			if diff < 0 {
-				diff = -diff
+	for i, b := range bankList {
+		if !used[i] {
+			unmatchedBank = append(unmatchedBank, b)
+		}
+	}
+	return pairs, unmatchedSys, unmatchedBank
+}
+
+// reconcileBucketOptimal solves the bucket as a minimum-cost bipartite
+// assignment: a cost matrix C[i][j] = |sys[i]-bank[j]| (in sys[i]'s
+// currency), padded on whichever side is shorter with dummy rows costing
+// opts.unmatchPenalty() so real pairs are preferred unless their delta
+// exceeds opts.MaxDeltaCents.
+func reconcileBucketOptimal(sysList []Transaction, bankList []BankStatement, opts ReconcileOptions) (pairs []MatchedPair, unmatchedSys []Transaction, unmatchedBank []BankStatement) {
+	nSys, nBank := len(sysList), len(bankList)
+	n := nSys
+	if nBank > n {
+		n = nBank
+	}
+	if n == 0 {
+		return nil, nil, nil
+	}
+
+	penalty := opts.unmatchPenalty()
+
+	cost := make([][]int64, n)
+	allowed := make([][]bool, n)
+	delta := make([][]Money, n)
+	rate := make([][]float64, n)
+	for i := 0; i < n; i++ {
+		cost[i] = make([]int64, n)
+		allowed[i] = make([]bool, n)
+		delta[i] = make([]Money, n)
+		rate[i] = make([]float64, n)
+		for j := 0; j < n; j++ {
+			if i >= nSys || j >= nBank {
+				cost[i][j] = int64(penalty)
+				continue
 			}
-			if bestIdx == -1 || diff < bestDiff {
-				bestIdx = i
-				bestDiff = diff
+			d, r, ok := pairDelta(sysList[i], bankList[j], opts.FX)
+			if ok && (opts.MaxDeltaCents <= 0 || d <= opts.MaxDeltaCents) {
+				cost[i][j] = int64(d)
+				allowed[i][j] = true
+				delta[i][j] = d
+				rate[i][j] = r
+			} else {
+				cost[i][j] = int64(penalty) + 1
 			}
 		}
-
-		// Remove chosen candidate and account for discrepancy.
-		candidates = append(candidates[:bestIdx], candidates[bestIdx+1:]...)
-		buckets[k] = candidates
-		discrepancy += bestDiff
-		matched++
 	}
 
-	// Remaining bank statements in buckets are unmatched, group by bank.
-	unmatchedBank := make(map[string][]BankStatement)
-	for _, rem := range buckets {
-		for _, b := range rem {
-			unmatchedBank[b.Bank] = append(unmatchedBank[b.Bank], b)
+	assignment := hungarianMinCost(cost)
+
+	bankUsed := make([]bool, nBank)
+	for i := 0; i < nSys; i++ {
+		j := assignment[i]
+		if j < nBank && allowed[i][j] {
+			pairs = append(pairs, MatchedPair{
+				System:           sysList[i],
+				Bank:             bankList[j],
+				DiscrepancyCents: delta[i][j],
+				AppliedFXRate:    rate[i][j],
+			})
+			bankUsed[j] = true
+		} else {
+			unmatchedSys = append(unmatchedSys, sysList[i])
 		}
 	}
 
-	return ReconciliationResult{
-		TotalSystemTransactions: len(sysIn),
-		TotalBankTransactions:   len(bankIn),
-		TotalProcessed:          len(sysIn) + len(bankIn),
-		MatchedCount:            matched,
-		UnmatchedSystem:         unmatchedSys,
-		UnmatchedBankByName:     unmatchedBank,
-		TotalDiscrepancyCents:   discrepancy,
+	for j := 0; j < nBank; j++ {
+		if !bankUsed[j] {
+			unmatchedBank = append(unmatchedBank, bankList[j])
+		}
 	}
+	return pairs, unmatchedSys, unmatchedBank
+}
+
+// convertAmount rescales amt from one currency's minor units into
+// another's, applying rate (1 for a same-currency, same-scale no-op).
+func convertAmount(amt Money, from, to Currency, rate float64) Money {
+	fromUnits := float64(amt) / float64(from.scale())
+	toMinor := fromUnits * rate * float64(to.scale())
+	return Money(math.Round(toMinor))
 }
@@ -1,6 +1,8 @@
 package reconcile
 
 import (
+	"context"
+	"os"
 	"testing"
 	"time"
 )
@@ -31,7 +33,7 @@ func TestBasicMatchAndDiscrepancy(t *testing.T) {
 		{UniqueIdentifier: "B2", AmountCents: 4998, Date: mustDate("2025-08-01"), Bank: "Alpha"},
 	}
 
-	res := Reconcile(sys, bank, mustDate("2025-08-01"), mustDate("2025-08-01"))
+	res := Reconcile(sys, bank, mustDate("2025-08-01"), mustDate("2025-08-01"), ReconcileOptions{})
 	if res.MatchedCount != 2 {
 		t.Fatalf("want 2 matches, got %d", res.MatchedCount)
 	}
@@ -58,7 +60,7 @@ func TestUnmatchedAndGrouping(t *testing.T) {
 		{UniqueIdentifier: "B5", AmountCents: -2500, Date: mustDate("2025-08-02"), Bank: "Alpha"},
 	}
 
-	res := Reconcile(sys, bank, mustDate("2025-08-02"), mustDate("2025-08-02"))
+	res := Reconcile(sys, bank, mustDate("2025-08-02"), mustDate("2025-08-02"), ReconcileOptions{})
 	if res.MatchedCount != 1 {
 		t.Fatalf("want 1 match, got %d", res.MatchedCount)
 	}
@@ -85,7 +87,7 @@ func TestTimeframeAndNearest(t *testing.T) {
 		{UniqueIdentifier: "BC", AmountCents: 1000, Date: mustDate("2025-08-04"), Bank: "Alpha"}, // out of range
 	}
 
-	res := Reconcile(sys, bank, mustDate("2025-08-01"), mustDate("2025-08-03"))
+	res := Reconcile(sys, bank, mustDate("2025-08-01"), mustDate("2025-08-03"), ReconcileOptions{})
 	if res.MatchedCount != 2 {
 		t.Fatalf("want 2 matches, got %d", res.MatchedCount)
 	}
@@ -109,7 +111,7 @@ func TestSignLogic(t *testing.T) {
 		{UniqueIdentifier: "Y", AmountCents: 1234, Date: mustDate("2025-08-10"), Bank: "Alpha"},
 	}
 
-	res := Reconcile(sys, bank, mustDate("2025-08-10"), mustDate("2025-08-10"))
+	res := Reconcile(sys, bank, mustDate("2025-08-10"), mustDate("2025-08-10"), ReconcileOptions{})
 	if res.MatchedCount != 2 {
 		t.Fatalf("want 2 matches, got %d", res.MatchedCount)
 	}
@@ -117,3 +119,481 @@ func TestSignLogic(t *testing.T) {
 		t.Fatalf("want 0 discrepancy, got %d", res.TotalDiscrepancyCents)
 	}
 }
+
+func TestJPYNoDecimals(t *testing.T) {
+	sys := []Transaction{
+		{TrxID: "J1", AmountCents: 5000, Type: CREDIT, TransactionTime: mustTime("2025-08-11T09:00:00Z"), CurrencyCode: "JPY"},
+	}
+	bank := []BankStatement{
+		{UniqueIdentifier: "JB1", AmountCents: 5000, Date: mustDate("2025-08-11"), Bank: "Mizuho", CurrencyCode: "JPY"},
+	}
+
+	res := Reconcile(sys, bank, mustDate("2025-08-11"), mustDate("2025-08-11"), ReconcileOptions{})
+	if res.MatchedCount != 1 {
+		t.Fatalf("want 1 match, got %d", res.MatchedCount)
+	}
+	if res.TotalDiscrepancyCents != 0 {
+		t.Fatalf("want 0 discrepancy, got %d", res.TotalDiscrepancyCents)
+	}
+	if got := Money(5000).Format(LookupCurrency("JPY")); got != "5000" {
+		t.Fatalf("want JPY amount formatted without decimals, got %q", got)
+	}
+}
+
+func TestCSVLoadersScaleAmountsByCurrencyPrecision(t *testing.T) {
+	dir := t.TempDir()
+
+	sysPath := dir + "/system.csv"
+	sysCSV := "trxID,amount,type,transactionTime,currency\n" +
+		"J1,1000,CREDIT,2025-08-11T09:00:00Z,JPY\n"
+	if err := os.WriteFile(sysPath, []byte(sysCSV), 0o644); err != nil {
+		t.Fatalf("write system fixture: %v", err)
+	}
+
+	bankPath := dir + "/bank.csv"
+	bankCSV := "unique_identifier,amount,date,bank,currency\n" +
+		"JB1,1000,2025-08-11,Mizuho,JPY\n"
+	if err := os.WriteFile(bankPath, []byte(bankCSV), 0o644); err != nil {
+		t.Fatalf("write bank fixture: %v", err)
+	}
+
+	sys, err := ParseSystemCSV(sysPath)
+	if err != nil {
+		t.Fatalf("ParseSystemCSV: %v", err)
+	}
+	if len(sys) != 1 || sys[0].AmountCents != 1000 {
+		t.Fatalf("want JPY amount \"1000\" to load as 1000 minor units (precision 0), got %+v", sys)
+	}
+
+	bank, err := ParseBankCSV(bankPath, "Mizuho")
+	if err != nil {
+		t.Fatalf("ParseBankCSV: %v", err)
+	}
+	if len(bank) != 1 || bank[0].AmountCents != 1000 {
+		t.Fatalf("want JPY amount \"1000\" to load as 1000 minor units (precision 0), got %+v", bank)
+	}
+}
+
+func TestCrossCurrencyRequiresFX(t *testing.T) {
+	sys := []Transaction{
+		{TrxID: "U1", AmountCents: 10000, Type: CREDIT, TransactionTime: mustTime("2025-08-12T09:00:00Z"), CurrencyCode: "USD"},
+	}
+	bank := []BankStatement{
+		{UniqueIdentifier: "EB1", AmountCents: 9100, Date: mustDate("2025-08-12"), Bank: "Deutsche", CurrencyCode: "EUR"},
+	}
+
+	// Without an FX table, USD and EUR rows never match.
+	res := Reconcile(sys, bank, mustDate("2025-08-12"), mustDate("2025-08-12"), ReconcileOptions{})
+	if res.MatchedCount != 0 {
+		t.Fatalf("want 0 matches without fx table, got %d", res.MatchedCount)
+	}
+
+	// 1 EUR = 1.10 USD, so 91.00 EUR converts to 100.10 USD (delta 10 cents).
+	fx := FXTable{"EUR/USD": 1.10}
+	res = Reconcile(sys, bank, mustDate("2025-08-12"), mustDate("2025-08-12"), ReconcileOptions{FX: fx})
+	if res.MatchedCount != 1 {
+		t.Fatalf("want 1 match with fx table, got %d", res.MatchedCount)
+	}
+	if res.TotalDiscrepancyCents != 10 {
+		t.Fatalf("want 10 cent discrepancy after conversion, got %d", res.TotalDiscrepancyCents)
+	}
+	if len(res.MatchedPairs) != 1 || res.MatchedPairs[0].AppliedFXRate != 1.10 {
+		t.Fatalf("want applied fx rate 1.10 recorded on the matched pair, got %+v", res.MatchedPairs)
+	}
+}
+
+func TestOptimalBeatsGreedyOnTotalDiscrepancy(t *testing.T) {
+	// Arrival order (by TransactionTime) is 4, 0, 3 - out of amount order,
+	// so greedy's locally-nearest pick for the first row (4) blocks the
+	// globally optimal sorted pairing (0<->1, 3<->2, 4<->6, total 4).
+	sys := []Transaction{
+		{TrxID: "S4", AmountCents: 4, Type: CREDIT, TransactionTime: mustTime("2025-08-13T09:00:00Z")},
+		{TrxID: "S0", AmountCents: 0, Type: CREDIT, TransactionTime: mustTime("2025-08-13T09:05:00Z")},
+		{TrxID: "S3", AmountCents: 3, Type: CREDIT, TransactionTime: mustTime("2025-08-13T09:10:00Z")},
+	}
+	bank := []BankStatement{
+		{UniqueIdentifier: "B1", AmountCents: 1, Date: mustDate("2025-08-13"), Bank: "Alpha"},
+		{UniqueIdentifier: "B2", AmountCents: 2, Date: mustDate("2025-08-13"), Bank: "Alpha"},
+		{UniqueIdentifier: "B6", AmountCents: 6, Date: mustDate("2025-08-13"), Bank: "Alpha"},
+	}
+
+	greedy := Reconcile(sys, bank, mustDate("2025-08-13"), mustDate("2025-08-13"), ReconcileOptions{MatchStrategy: Greedy})
+	if greedy.TotalDiscrepancyCents != 6 {
+		t.Fatalf("want greedy discrepancy 6, got %d", greedy.TotalDiscrepancyCents)
+	}
+
+	optimal := Reconcile(sys, bank, mustDate("2025-08-13"), mustDate("2025-08-13"), ReconcileOptions{MatchStrategy: Optimal})
+	if optimal.TotalDiscrepancyCents != 4 {
+		t.Fatalf("want optimal discrepancy 4, got %d", optimal.TotalDiscrepancyCents)
+	}
+	if optimal.MatchedCount != 3 {
+		t.Fatalf("want 3 matches, got %d", optimal.MatchedCount)
+	}
+}
+
+func TestRuleBasedDateToleranceAndSignInvert(t *testing.T) {
+	sys := []Transaction{
+		{TrxID: "PP1", AmountCents: 10000, Type: CREDIT, TransactionTime: mustTime("2025-08-15T09:00:00Z"), Description: "PayPal payout #42"},
+	}
+	bank := []BankStatement{
+		// Arrives 2 days later, booked as a debit by this bank, and nets a 150-cent fee.
+		{UniqueIdentifier: "PYPL-42", AmountCents: -9850, Date: mustDate("2025-08-17"), Bank: "Chase"},
+	}
+
+	rules := MatchRules{
+		{
+			Name:                 "paypal-payout",
+			DescriptionRegex:     `^PayPal payout`,
+			DateToleranceDays:    3,
+			AmountToleranceCents: 200,
+			SignInvert:           true,
+		},
+	}
+	if err := rules.compile(); err != nil {
+		t.Fatalf("compile: %v", err)
+	}
+
+	res := Reconcile(sys, bank, mustDate("2025-08-15"), mustDate("2025-08-18"), ReconcileOptions{Rules: rules})
+	if res.MatchedCount != 1 {
+		t.Fatalf("want 1 match, got %d (unmatched sys=%v bank=%v)", res.MatchedCount, res.UnmatchedSystem, res.UnmatchedBankByName)
+	}
+	if got := res.MatchedPairs[0].Reason; got != "paypal-payout" {
+		t.Fatalf("want Reason %q, got %q", "paypal-payout", got)
+	}
+	if res.TotalDiscrepancyCents != 150 {
+		t.Fatalf("want 150 cent discrepancy (fee), got %d", res.TotalDiscrepancyCents)
+	}
+	if res.RuleCounts["paypal-payout"] != 1 {
+		t.Fatalf("want rule counter 1, got %d", res.RuleCounts["paypal-payout"])
+	}
+}
+
+func TestRuleBasedFallsThroughToDefaultRule(t *testing.T) {
+	sys := []Transaction{
+		{TrxID: "ORD-1", AmountCents: 5000, Type: CREDIT, TransactionTime: mustTime("2025-08-20T09:00:00Z"), Description: "Stripe payout"},
+		{TrxID: "ORD-2", AmountCents: 3000, Type: CREDIT, TransactionTime: mustTime("2025-08-20T10:00:00Z"), Description: "Unrelated deposit"},
+	}
+	bank := []BankStatement{
+		{UniqueIdentifier: "B1", AmountCents: 5000, Date: mustDate("2025-08-20"), Bank: "Alpha"},
+		{UniqueIdentifier: "B2", AmountCents: 3000, Date: mustDate("2025-08-20"), Bank: "Alpha"},
+	}
+
+	rules := MatchRules{
+		{Name: "stripe", DescriptionRegex: `^Stripe`},
+		{Name: "default"}, // no predicates: governs everything else
+	}
+	if err := rules.compile(); err != nil {
+		t.Fatalf("compile: %v", err)
+	}
+
+	res := Reconcile(sys, bank, mustDate("2025-08-20"), mustDate("2025-08-20"), ReconcileOptions{Rules: rules})
+	if res.MatchedCount != 2 {
+		t.Fatalf("want 2 matches, got %d", res.MatchedCount)
+	}
+	if res.RuleCounts["stripe"] != 1 || res.RuleCounts["default"] != 1 {
+		t.Fatalf("want 1 match per rule, got %+v", res.RuleCounts)
+	}
+}
+
+func TestStateStoreSkipsMatchedAndCarriesForwardUnmatched(t *testing.T) {
+	dir := t.TempDir()
+	statePath := dir + "/state.json"
+
+	sys := []Transaction{
+		{TrxID: "TX1", AmountCents: 10000, Type: DEBIT, TransactionTime: mustTime("2025-09-01T10:00:00Z")},
+		{TrxID: "TX2", AmountCents: 5000, Type: CREDIT, TransactionTime: mustTime("2025-09-01T11:00:00Z")},
+	}
+	bank := []BankStatement{
+		{UniqueIdentifier: "B1", AmountCents: -10000, Date: mustDate("2025-09-01"), Bank: "Alpha"},
+	}
+
+	store := NewStateStore(statePath)
+	if err := store.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	sysIn, bankIn := store.FilterKnown(sys, bank)
+	if len(sysIn) != 2 || len(bankIn) != 1 {
+		t.Fatalf("want everything passed through on first run, got sys=%d bank=%d", len(sysIn), len(bankIn))
+	}
+
+	res := Reconcile(sysIn, bankIn, mustDate("2025-09-01"), mustDate("2025-09-01"), ReconcileOptions{})
+	if res.MatchedCount != 1 || len(res.UnmatchedSystem) != 1 {
+		t.Fatalf("want 1 match and 1 unmatched system row, got matched=%d unmatchedSys=%d", res.MatchedCount, len(res.UnmatchedSystem))
+	}
+	if err := store.Commit(res); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+
+	// Re-run against a fresh store loaded from disk: TX1/B1 were matched
+	// and should be skipped; TX2 was left unmatched and should be
+	// carried forward even though this run's input no longer includes it.
+	store2 := NewStateStore(statePath)
+	if err := store2.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	newBank := []BankStatement{
+		{UniqueIdentifier: "B2", AmountCents: 5000, Date: mustDate("2025-09-01"), Bank: "Alpha"},
+	}
+	sysIn2, bankIn2 := store2.FilterKnown(nil, newBank)
+	if len(sysIn2) != 1 || sysIn2[0].TrxID != "TX2" {
+		t.Fatalf("want TX2 carried forward, got %+v", sysIn2)
+	}
+	if len(bankIn2) != 1 || bankIn2[0].UniqueIdentifier != "B2" {
+		t.Fatalf("want only the new bank row, got %+v", bankIn2)
+	}
+
+	res2 := Reconcile(sysIn2, bankIn2, mustDate("2025-09-01"), mustDate("2025-09-01"), ReconcileOptions{})
+	if res2.MatchedCount != 1 {
+		t.Fatalf("want TX2 to match B2 on the second run, got %d", res2.MatchedCount)
+	}
+}
+
+func TestStateStoreReset(t *testing.T) {
+	dir := t.TempDir()
+	statePath := dir + "/state.json"
+
+	store := NewStateStore(statePath)
+	res := ReconciliationResult{
+		MatchedPairs: []MatchedPair{{
+			System: Transaction{TrxID: "TX1", AmountCents: 100, Type: DEBIT, TransactionTime: mustTime("2025-09-02T00:00:00Z")},
+			Bank:   BankStatement{UniqueIdentifier: "B1", AmountCents: -100, Date: mustDate("2025-09-02"), Bank: "Alpha"},
+		}},
+	}
+	if err := store.Commit(res); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+	if _, err := os.Stat(statePath); err != nil {
+		t.Fatalf("want state file written, got %v", err)
+	}
+
+	if err := store.Reset(); err != nil {
+		t.Fatalf("reset: %v", err)
+	}
+	if _, err := os.Stat(statePath); !os.IsNotExist(err) {
+		t.Fatalf("want state file removed after reset, got err=%v", err)
+	}
+}
+
+func TestReconcileParallelMatchesSerial(t *testing.T) {
+	sys := []Transaction{
+		{TrxID: "TX1", AmountCents: 10000, Type: DEBIT, TransactionTime: mustTime("2025-09-05T10:00:00Z")},
+		{TrxID: "TX2", AmountCents: 5000, Type: CREDIT, TransactionTime: mustTime("2025-09-05T11:00:00Z")},
+		{TrxID: "TX3", AmountCents: 2500, Type: CREDIT, TransactionTime: mustTime("2025-09-06T09:00:00Z")},
+	}
+	bank := []BankStatement{
+		{UniqueIdentifier: "B1", AmountCents: -10000, Date: mustDate("2025-09-05"), Bank: "Alpha"},
+		{UniqueIdentifier: "B2", AmountCents: 4998, Date: mustDate("2025-09-05"), Bank: "Alpha"},
+		{UniqueIdentifier: "B3", AmountCents: 9999, Date: mustDate("2025-09-06"), Bank: "Beta"},
+	}
+
+	serial := Reconcile(sys, bank, mustDate("2025-09-05"), mustDate("2025-09-06"), ReconcileOptions{})
+	parallel := ReconcileParallel(context.Background(), sys, bank, mustDate("2025-09-05"), mustDate("2025-09-06"), ReconcileOptions{Threads: 4})
+
+	if parallel.MatchedCount != serial.MatchedCount {
+		t.Fatalf("matched count mismatch: serial=%d parallel=%d", serial.MatchedCount, parallel.MatchedCount)
+	}
+	if parallel.TotalDiscrepancyCents != serial.TotalDiscrepancyCents {
+		t.Fatalf("discrepancy mismatch: serial=%d parallel=%d", serial.TotalDiscrepancyCents, parallel.TotalDiscrepancyCents)
+	}
+	if len(parallel.UnmatchedSystem) != len(serial.UnmatchedSystem) {
+		t.Fatalf("unmatched system count mismatch: serial=%d parallel=%d", len(serial.UnmatchedSystem), len(parallel.UnmatchedSystem))
+	}
+	for i := range serial.UnmatchedSystem {
+		if serial.UnmatchedSystem[i].TrxID != parallel.UnmatchedSystem[i].TrxID {
+			t.Fatalf("unmatched system ordering mismatch at %d: serial=%s parallel=%s", i, serial.UnmatchedSystem[i].TrxID, parallel.UnmatchedSystem[i].TrxID)
+		}
+	}
+}
+
+func TestReconcileParallelRespectsCancellation(t *testing.T) {
+	sys := []Transaction{
+		{TrxID: "TX1", AmountCents: 10000, Type: DEBIT, TransactionTime: mustTime("2025-09-07T10:00:00Z")},
+	}
+	bank := []BankStatement{
+		{UniqueIdentifier: "B1", AmountCents: -10000, Date: mustDate("2025-09-07"), Bank: "Alpha"},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	res := ReconcileParallel(ctx, sys, bank, mustDate("2025-09-07"), mustDate("2025-09-07"), ReconcileOptions{})
+	if res.MatchedCount != 0 {
+		t.Fatalf("want 0 matches once ctx is already cancelled, got %d", res.MatchedCount)
+	}
+	if len(res.UnmatchedSystem) != 1 {
+		t.Fatalf("want the bucket's system row folded into unmatched, got %d", len(res.UnmatchedSystem))
+	}
+}
+
+func TestOptimalLeavesTooFarPairsUnmatched(t *testing.T) {
+	sys := []Transaction{
+		{TrxID: "S1", AmountCents: 10000, Type: CREDIT, TransactionTime: mustTime("2025-08-14T09:00:00Z")},
+	}
+	bank := []BankStatement{
+		{UniqueIdentifier: "B1", AmountCents: 50000, Date: mustDate("2025-08-14"), Bank: "Alpha"},
+	}
+
+	res := Reconcile(sys, bank, mustDate("2025-08-14"), mustDate("2025-08-14"), ReconcileOptions{
+		MatchStrategy: Optimal,
+		MaxDeltaCents: 1000,
+	})
+	if res.MatchedCount != 0 {
+		t.Fatalf("want 0 matches when delta exceeds MaxDeltaCents, got %d", res.MatchedCount)
+	}
+	if len(res.UnmatchedSystem) != 1 || len(res.UnmatchedBankByName["Alpha"]) != 1 {
+		t.Fatalf("want both sides reported unmatched, got sys=%d bank=%v", len(res.UnmatchedSystem), res.UnmatchedBankByName)
+	}
+}
+
+func TestParseBankMT940DebitCreditAndInfoContinuation(t *testing.T) {
+	const sta = `:20:STMT0001
+:25:ACC123
+:28C:00001/001
+:60F:C250801EUR1000,00
+:61:2508010801D500,00//CUST-REF-1
+:86:Payment to supplier ABC
+Invoice 123 continuation
+:61:2508020802C750,50//CUST-REF-2
+:86:Incoming wire transfer
+:62F:C250802EUR1250,50
+-
+`
+	dir := t.TempDir()
+	path := dir + "/statement.sta"
+	if err := os.WriteFile(path, []byte(sta), 0o644); err != nil {
+		t.Fatalf("write fixture: %v", err)
+	}
+
+	rows, err := ParseBankMT940(path, "TestBank")
+	if err != nil {
+		t.Fatalf("ParseBankMT940: %v", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("want 2 rows, got %d: %+v", len(rows), rows)
+	}
+
+	debit := rows[0]
+	if debit.AmountCents != -50000 {
+		t.Errorf("debit: want AmountCents -50000, got %d", debit.AmountCents)
+	}
+	if !debit.Date.Equal(mustDate("2025-08-01")) {
+		t.Errorf("debit: want date 2025-08-01, got %v", debit.Date)
+	}
+	if debit.CurrencyCode != "EUR" {
+		t.Errorf("debit: want currency EUR (propagated from :60F:), got %q", debit.CurrencyCode)
+	}
+	if debit.Bank != "TestBank" {
+		t.Errorf("debit: want bank TestBank, got %q", debit.Bank)
+	}
+	if debit.Description != "Payment to supplier ABC Invoice 123 continuation" {
+		t.Errorf("debit: want :86: continuation line folded into description, got %q", debit.Description)
+	}
+	if debit.UniqueIdentifier != "//CUST-REF-1" {
+		t.Errorf("debit: want structured :61: reference as UniqueIdentifier, got %q", debit.UniqueIdentifier)
+	}
+
+	credit := rows[1]
+	if credit.AmountCents != 75050 {
+		t.Errorf("credit: want AmountCents 75050, got %d", credit.AmountCents)
+	}
+	if !credit.Date.Equal(mustDate("2025-08-02")) {
+		t.Errorf("credit: want date 2025-08-02, got %v", credit.Date)
+	}
+	if credit.Description != "Incoming wire transfer" {
+		t.Errorf("credit: want description from :86:, got %q", credit.Description)
+	}
+	if credit.UniqueIdentifier != "//CUST-REF-2" {
+		t.Errorf("credit: want structured :61: reference as UniqueIdentifier (not the :86: text), got %q", credit.UniqueIdentifier)
+	}
+}
+
+func TestParseBankMT940FallsBackToInfoWhenReferenceMissing(t *testing.T) {
+	const sta = `:25:ACC999
+:60F:C250901USD100,00
+:61:2509010901C100,00
+:86:Generic deposit text
+:62F:C250901USD100,00
+-
+`
+	dir := t.TempDir()
+	path := dir + "/statement.sta"
+	if err := os.WriteFile(path, []byte(sta), 0o644); err != nil {
+		t.Fatalf("write fixture: %v", err)
+	}
+
+	rows, err := ParseBankMT940(path, "TestBank")
+	if err != nil {
+		t.Fatalf("ParseBankMT940: %v", err)
+	}
+	if len(rows) != 1 {
+		t.Fatalf("want 1 row, got %d: %+v", len(rows), rows)
+	}
+	if rows[0].UniqueIdentifier != "Generic deposit text" {
+		t.Errorf("want :86: text as UniqueIdentifier fallback when :61: carries no reference, got %q", rows[0].UniqueIdentifier)
+	}
+}
+
+func TestParseBankCSVWithProfileNonDefaultColumnsAndSign(t *testing.T) {
+	const csvFixture = "Ref,Betrag,Buchungstag,Soll/Haben,Hinweis\n" +
+		"\"order no. TX-9001 settled\",\"1.234,56\",02.09.2025,H,\"payment received\"\n" +
+		"\"order no. TX-9002 settled\",\"500,00\",03.09.2025,S,\"fee charged\"\n"
+
+	dir := t.TempDir()
+	path := dir + "/bank.csv"
+	if err := os.WriteFile(path, []byte(csvFixture), 0o644); err != nil {
+		t.Fatalf("write fixture: %v", err)
+	}
+
+	profile := &BankProfile{
+		Name: "Sparkasse",
+		ColumnAliases: map[string][]string{
+			"unique_identifier": {"Ref"},
+			"amount":            {"Betrag"},
+			"date":              {"Buchungstag"},
+			"description":       {"Hinweis"},
+		},
+		DateLayout:             "02.01.2006",
+		DecimalSeparator:       ",",
+		ThousandsSeparator:     ".",
+		SignColumn:             "Soll/Haben",
+		DebitTokens:            []string{"S"},
+		CreditTokens:           []string{"H"},
+		UniqueIdentifierRegex:  `TX-(\d+)`,
+		UniqueIdentifierColumn: "Ref",
+		DefaultBank:            "Sparkasse",
+		DefaultCurrency:        "EUR",
+	}
+
+	rows, err := ParseBankCSVWithProfile(path, profile)
+	if err != nil {
+		t.Fatalf("ParseBankCSVWithProfile: %v", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("want 2 rows, got %d: %+v", len(rows), rows)
+	}
+
+	credit := rows[0]
+	if credit.UniqueIdentifier != "9001" {
+		t.Errorf("want unique_identifier_regex capture group \"9001\", got %q", credit.UniqueIdentifier)
+	}
+	if credit.AmountCents != 123456 {
+		t.Errorf("want thousands/decimal separators parsed to 123456 cents, got %d", credit.AmountCents)
+	}
+	if !credit.Date.Equal(mustDate("2025-09-02")) {
+		t.Errorf("want date 2025-09-02, got %v", credit.Date)
+	}
+	if credit.Bank != "Sparkasse" {
+		t.Errorf("want default bank Sparkasse, got %q", credit.Bank)
+	}
+	if credit.CurrencyCode != "EUR" {
+		t.Errorf("want default currency EUR, got %q", credit.CurrencyCode)
+	}
+
+	debit := rows[1]
+	if debit.UniqueIdentifier != "9002" {
+		t.Errorf("want unique_identifier_regex capture group \"9002\", got %q", debit.UniqueIdentifier)
+	}
+	if debit.AmountCents != -50000 {
+		t.Errorf("want sign column \"S\" to make amount negative, got %d", debit.AmountCents)
+	}
+}
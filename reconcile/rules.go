@@ -0,0 +1,283 @@
+package reconcile
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	timepkg "time"
+)
+
+// -------- Rule-based matching --------
+//
+// MatchRules generalizes the hardcoded (date, sign) bucketing used by
+// Reconcile's default path: each rule narrows which system rows it
+// governs (via DescriptionRegex), which bank rows are eligible partners
+// (via BankRegex), and how much slack to allow when pairing them up.
+// This lets institution-specific quirks ("PayPal payouts arrive 1-3
+// days later and net fees") be encoded as data instead of code.
+
+// MatchRule describes one such quirk. Rules are tried in declared
+// order; the first rule whose DescriptionRegex matches a system row
+// governs how that row is matched - later rules are never consulted for
+// it, even if no bank row within tolerance is found.
+type MatchRule struct {
+	// Name identifies the rule in MatchedPair.Reason and
+	// ReconciliationResult.RuleCounts. Defaults to "rule-<index>" when empty.
+	Name string `json:"name"`
+
+	// DescriptionRegex, if set, must match the system row's Description
+	// (falling back to TrxID when Description is empty) for this rule to
+	// govern that row. Empty means the rule governs every row not
+	// claimed by an earlier rule.
+	DescriptionRegex string `json:"description_regex"`
+	// BankRegex, if set, must match a candidate bank row's Description
+	// (falling back to UniqueIdentifier) for it to be considered.
+	BankRegex string `json:"bank_regex"`
+
+	// DateToleranceDays allows the bank row's date to fall within +/-
+	// this many days of the system row's date (e.g. weekend settlement).
+	DateToleranceDays int `json:"date_tolerance_days"`
+
+	// AmountToleranceCents and AmountTolerancePct bound the acceptable
+	// delta between the two amounts, in the system row's currency. When
+	// both are set, the larger of the two resolved amounts wins.
+	AmountToleranceCents Money   `json:"amount_tolerance_cents"`
+	AmountTolerancePct   float64 `json:"amount_tolerance_pct"`
+
+	// SignInvert flips the bank row's sign before comparing, for banks
+	// that book payouts with the opposite sign convention.
+	SignInvert bool `json:"sign_invert"`
+
+	descRe *regexp.Regexp
+	bankRe *regexp.Regexp
+}
+
+// MatchRules is an ordered, first-match-wins list of MatchRule.
+type MatchRules []MatchRule
+
+// LoadMatchRules reads a MatchRules config from a JSON file and compiles
+// its regexes.
+func LoadMatchRules(path string) (MatchRules, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var rules MatchRules
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("match rules %s: %w", path, err)
+	}
+	if err := rules.compile(); err != nil {
+		return nil, fmt.Errorf("match rules %s: %w", path, err)
+	}
+	return rules, nil
+}
+
+// compile resolves and validates each rule's regexes in place. Callers
+// that build MatchRules programmatically (rather than via
+// LoadMatchRules) must call this before passing them to Reconcile.
+func (rules MatchRules) compile() error {
+	for i := range rules {
+		if err := rules[i].compile(); err != nil {
+			return fmt.Errorf("rule %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func (r *MatchRule) compile() error {
+	if r.DescriptionRegex != "" {
+		re, err := regexp.Compile(r.DescriptionRegex)
+		if err != nil {
+			return fmt.Errorf("description_regex: %w", err)
+		}
+		r.descRe = re
+	}
+	if r.BankRegex != "" {
+		re, err := regexp.Compile(r.BankRegex)
+		if err != nil {
+			return fmt.Errorf("bank_regex: %w", err)
+		}
+		r.bankRe = re
+	}
+	return nil
+}
+
+func (r MatchRule) name(idx int) string {
+	if r.Name != "" {
+		return r.Name
+	}
+	return fmt.Sprintf("rule-%d", idx)
+}
+
+func sysDescription(tx Transaction) string {
+	if tx.Description != "" {
+		return tx.Description
+	}
+	return tx.TrxID
+}
+
+func bankDescription(b BankStatement) string {
+	if b.Description != "" {
+		return b.Description
+	}
+	return b.UniqueIdentifier
+}
+
+func (r MatchRule) matchesSys(tx Transaction) bool {
+	if r.descRe == nil {
+		return true
+	}
+	return r.descRe.MatchString(sysDescription(tx))
+}
+
+func (r MatchRule) matchesBank(b BankStatement) bool {
+	if r.bankRe == nil {
+		return true
+	}
+	return r.bankRe.MatchString(bankDescription(b))
+}
+
+func (r MatchRule) withinDate(sysDate, bankDate timepkg.Time) bool {
+	diffHours := sysDate.Sub(bankDate).Hours()
+	if diffHours < 0 {
+		diffHours = -diffHours
+	}
+	// +1h slack absorbs DST-shifted midnights without loosening whole days.
+	return diffHours <= float64(r.DateToleranceDays)*24+1
+}
+
+// amountWithin reports whether tx and b, after SignInvert and FX
+// conversion, fall within this rule's amount tolerance. delta and rate
+// mirror pairDelta's result (computed against the sign-adjusted copy of b).
+func (r MatchRule) amountWithin(tx Transaction, b BankStatement, fx FXTable) (delta Money, rate float64, ok bool) {
+	if r.SignInvert {
+		b.AmountCents = -b.AmountCents
+	}
+	if signOf(tx.SignedAmount()) != signOf(b.AmountCents) {
+		return 0, 0, false
+	}
+
+	delta, rate, ok = pairDelta(tx, b, fx)
+	if !ok {
+		return 0, 0, false
+	}
+
+	tol := r.AmountToleranceCents
+	if r.AmountTolerancePct > 0 {
+		pctTol := absMoney(Money(math.Round(float64(tx.SignedAmount()) * r.AmountTolerancePct / 100)))
+		if pctTol > tol {
+			tol = pctTol
+		}
+	}
+	return delta, rate, delta <= tol
+}
+
+func absMoney(m Money) Money {
+	if m < 0 {
+		return -m
+	}
+	return m
+}
+
+// reconcileWithRules matches system and bank rows using opts.Rules
+// instead of Reconcile's fixed (date, sign) bucketing. For each system
+// row (processed in time order), the first rule whose DescriptionRegex
+// matches governs the search for a partner bank row among those still
+// unused; the nearest candidate within that rule's tolerances wins.
+func reconcileWithRules(system []Transaction, bank []BankStatement, start, end timepkg.Time, opts ReconcileOptions) ReconciliationResult {
+	rules := opts.Rules
+
+	sysIn := make([]Transaction, 0, len(system))
+	for _, t := range system {
+		td := t.DateOnly()
+		if !td.Before(start) && !td.After(end) {
+			sysIn = append(sysIn, t)
+		}
+	}
+	bankIn := make([]BankStatement, 0, len(bank))
+	for _, b := range bank {
+		if !b.Date.Before(start) && !b.Date.After(end) {
+			bankIn = append(bankIn, b)
+		}
+	}
+
+	sys := append([]Transaction(nil), sysIn...)
+	sortByTime(sys)
+
+	used := make([]bool, len(bankIn))
+	ruleCounts := make(map[string]int)
+
+	var pairs []MatchedPair
+	var unmatchedSys []Transaction
+	var discrepancy Money
+
+	for _, tx := range sys {
+		ruleIdx := -1
+		for i, r := range rules {
+			if r.matchesSys(tx) {
+				ruleIdx = i
+				break
+			}
+		}
+		if ruleIdx == -1 {
+			unmatchedSys = append(unmatchedSys, tx)
+			continue
+		}
+		rule := rules[ruleIdx]
+
+		bestIdx := -1
+		var bestDelta Money
+		var bestRate float64
+		for i, b := range bankIn {
+			if used[i] || !rule.matchesBank(b) || !rule.withinDate(tx.DateOnly(), b.Date) {
+				continue
+			}
+			delta, rate, ok := rule.amountWithin(tx, b, opts.FX)
+			if !ok {
+				continue
+			}
+			if bestIdx == -1 || delta < bestDelta {
+				bestIdx, bestDelta, bestRate = i, delta, rate
+			}
+		}
+
+		if bestIdx == -1 {
+			unmatchedSys = append(unmatchedSys, tx)
+			continue
+		}
+		used[bestIdx] = true
+		name := rule.name(ruleIdx)
+		ruleCounts[name]++
+		discrepancy += bestDelta
+		pairs = append(pairs, MatchedPair{
+			System:           tx,
+			Bank:             bankIn[bestIdx],
+			DiscrepancyCents: bestDelta,
+			AppliedFXRate:    bestRate,
+			Reason:           name,
+		})
+	}
+
+	unmatchedBank := make(map[string][]BankStatement)
+	for i, b := range bankIn {
+		if !used[i] {
+			unmatchedBank[b.Bank] = append(unmatchedBank[b.Bank], b)
+		}
+	}
+
+	sortByTime(unmatchedSys)
+
+	return ReconciliationResult{
+		TotalSystemTransactions: len(sysIn),
+		TotalBankTransactions:   len(bankIn),
+		TotalProcessed:          len(sysIn) + len(bankIn),
+		MatchedCount:            len(pairs),
+		MatchedPairs:            pairs,
+		UnmatchedSystem:         unmatchedSys,
+		UnmatchedBankByName:     unmatchedBank,
+		TotalDiscrepancyCents:   discrepancy,
+		RuleCounts:              ruleCounts,
+	}
+}
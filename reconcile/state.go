@@ -0,0 +1,165 @@
+package reconcile
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// -------- Incremental state (content-hash dedup across runs) --------
+//
+// Re-running Reconcile against overlapping date ranges would otherwise
+// redo all matching work and double-report unmatched items every run.
+// StateStore persists, keyed by a stable content hash of each row, which
+// system/bank rows were already matched in a prior run, and carries
+// forward previously-unmatched rows so they get another chance to pair
+// with newly arrived counterparties.
+//
+// Only a JSON-backed store is implemented here; a SQLite backend would
+// need a driver this tree doesn't vendor.
+
+// stateFile is the on-disk shape of a StateStore.
+type stateFile struct {
+	MatchedSystemHashes map[string]bool `json:"matched_system_hashes"`
+	MatchedBankHashes   map[string]bool `json:"matched_bank_hashes"`
+	UnmatchedSystem     []Transaction   `json:"unmatched_system"`
+	UnmatchedBank       []BankStatement `json:"unmatched_bank"`
+}
+
+// StateStore tracks reconciliation state across runs against a JSON
+// file at Path.
+type StateStore struct {
+	Path string
+
+	data stateFile
+}
+
+// NewStateStore returns a StateStore backed by the JSON file at path.
+// Call Load before use; the file need not exist yet.
+func NewStateStore(path string) *StateStore {
+	return &StateStore{Path: path, data: emptyStateFile()}
+}
+
+func emptyStateFile() stateFile {
+	return stateFile{
+		MatchedSystemHashes: map[string]bool{},
+		MatchedBankHashes:   map[string]bool{},
+	}
+}
+
+// Load reads prior state from disk. A missing file is not an error - it
+// means no prior run exists yet.
+func (s *StateStore) Load() error {
+	data, err := os.ReadFile(s.Path)
+	if os.IsNotExist(err) {
+		s.data = emptyStateFile()
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, &s.data); err != nil {
+		return fmt.Errorf("state file %s: %w", s.Path, err)
+	}
+	if s.data.MatchedSystemHashes == nil {
+		s.data.MatchedSystemHashes = map[string]bool{}
+	}
+	if s.data.MatchedBankHashes == nil {
+		s.data.MatchedBankHashes = map[string]bool{}
+	}
+	return nil
+}
+
+// Reset wipes any in-memory and on-disk state, starting the next run
+// from a blank slate.
+func (s *StateStore) Reset() error {
+	s.data = emptyStateFile()
+	if err := os.Remove(s.Path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// FilterKnown drops rows already recorded as matched in a prior run, and
+// carries forward rows a prior run left unmatched (deduped against the
+// incoming set by hash) so they get another chance against newly
+// arrived counterparties.
+func (s *StateStore) FilterKnown(system []Transaction, bank []BankStatement) ([]Transaction, []BankStatement) {
+	incomingSys := make(map[string]bool, len(system))
+	outSys := make([]Transaction, 0, len(system))
+	for _, t := range system {
+		h := hashSystemRow(t)
+		incomingSys[h] = true
+		if s.data.MatchedSystemHashes[h] {
+			continue
+		}
+		outSys = append(outSys, t)
+	}
+	for _, t := range s.data.UnmatchedSystem {
+		h := hashSystemRow(t)
+		if incomingSys[h] || s.data.MatchedSystemHashes[h] {
+			continue
+		}
+		outSys = append(outSys, t)
+	}
+
+	incomingBank := make(map[string]bool, len(bank))
+	outBank := make([]BankStatement, 0, len(bank))
+	for _, b := range bank {
+		h := hashBankRow(b)
+		incomingBank[h] = true
+		if s.data.MatchedBankHashes[h] {
+			continue
+		}
+		outBank = append(outBank, b)
+	}
+	for _, b := range s.data.UnmatchedBank {
+		h := hashBankRow(b)
+		if incomingBank[h] || s.data.MatchedBankHashes[h] {
+			continue
+		}
+		outBank = append(outBank, b)
+	}
+
+	return outSys, outBank
+}
+
+// Commit records res's matched rows as known-matched, replaces the
+// carried-forward unmatched sets with res's leftovers, and persists the
+// result to disk.
+func (s *StateStore) Commit(res ReconciliationResult) error {
+	for _, p := range res.MatchedPairs {
+		s.data.MatchedSystemHashes[hashSystemRow(p.System)] = true
+		s.data.MatchedBankHashes[hashBankRow(p.Bank)] = true
+	}
+
+	s.data.UnmatchedSystem = append([]Transaction(nil), res.UnmatchedSystem...)
+	var unmatchedBank []BankStatement
+	for _, rows := range res.UnmatchedBankByName {
+		unmatchedBank = append(unmatchedBank, rows...)
+	}
+	s.data.UnmatchedBank = unmatchedBank
+
+	out, err := json.MarshalIndent(s.data, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.Path, out, 0644)
+}
+
+// hashSystemRow derives a stable content hash for a system row, used as
+// the StateStore dedup key.
+func hashSystemRow(t Transaction) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%d|%s|%s", t.TrxID, t.AmountCents, t.Type, t.TransactionTime.Format(time.RFC3339))))
+	return hex.EncodeToString(sum[:])
+}
+
+// hashBankRow derives a stable content hash for a bank row, used as the
+// StateStore dedup key.
+func hashBankRow(b BankStatement) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%s|%d|%s", b.Bank, b.UniqueIdentifier, b.AmountCents, b.Date.Format("2006-01-02"))))
+	return hex.EncodeToString(sum[:])
+}